@@ -14,9 +14,12 @@ import (
 	"time"
 
 	"github.com/grafana/loki/pkg/push"
-	"github.com/klauspost/compress/s2"
 )
 
+// number of goroutines concurrently performing HTTP sends, so a slow/unavailable
+// Loki instance doesn't stall the batching goroutine.
+const sendWorkers = 4
+
 type ClientOptions struct {
 	// Default labels passed with each message
 	DefaultLabels map[string]string
@@ -35,6 +38,46 @@ type ClientOptions struct {
 
 	// User json instead of protobuf for log pushing
 	UseJSON bool
+
+	// Maximum number of retry attempts for a batch that receives a retriable
+	// error (429 or 5xx). Default: 0 (no retries).
+	MaxRetries int
+	// Minimum/maximum backoff between retry attempts, used by a decorrelated
+	// jitter backoff. Defaults: 500ms / 1m. A Retry-After response header, if
+	// present, is honored when it is longer than the computed backoff.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+
+	// If set, batches that exhaust their retries (or fail while the client is
+	// shutting down) are persisted as append-only segment files under this
+	// directory instead of being dropped. Use ReplayDeadLetter to re-send them.
+	DeadLetterDir string
+
+	// Size of the ingestion queue between Log and the batching goroutine.
+	// Default: 1000.
+	QueueSize uint
+	// What to do with a Log call when the ingestion queue is full.
+	// Default: OverflowBlock.
+	OverflowPolicy OverflowPolicy
+	// Called, if set, whenever a record is dropped by OverflowPolicy.
+	DropCallback func(stream push.Stream)
+
+	// Static X-Scope-OrgID sent with every push request. Ignored for a stream
+	// if TenantResolver is set and returns a non-empty tenant for it.
+	TenantID string
+	// Resolves the tenant (X-Scope-OrgID) to route a stream to, based on its
+	// labels. Useful to strip a routing label (e.g. "tenant") out of labels
+	// before it is passed on, since the resolver receives the same map that
+	// is used to build the stream.
+	TenantResolver func(labels map[string]string) string
+
+	// Compression applied to the push request body, for both JSON and
+	// protobuf. Default: CompressionSnappy for protobuf, CompressionNone for
+	// JSON, matching the client's original behavior. Ignored if Encoder is set.
+	Compression Compression
+	// Encoder overrides Compression with a custom codec, e.g. for pooled or
+	// tuned zstd encoders.
+	Encoder Encoder
 }
 
 type HTTPClient interface {
@@ -45,6 +88,15 @@ type Client interface {
 	Log(ts time.Time, msg string, lables, metadata map[string]string)
 	Flush(ctx context.Context)
 	Shutdown(ctx context.Context)
+
+	// ReplayDeadLetter re-sends every batch persisted to DeadLetterDir, in the
+	// order they were written, removing each segment as it is fully replayed.
+	// It is a no-op if DeadLetterDir was not configured. Stops at the first
+	// error, leaving the remaining segments in place for a later retry.
+	ReplayDeadLetter(ctx context.Context) error
+
+	// Stats returns a snapshot of the client's ingestion and delivery counters.
+	Stats() Stats
 }
 
 type clientImpl struct {
@@ -54,15 +106,40 @@ type clientImpl struct {
 	flush  chan struct{}
 	closed bool
 
-	entries     chan push.Stream
+	entries     chan queuedStream
 	waitGroup   sync.WaitGroup
 	errCallback func(err error)
 
+	tenantID       string
+	tenantResolver func(labels map[string]string) string
+
 	maxBatchSize int
 	maxBatchWait time.Duration
 
 	useJSON bool
 	client  HTTPClient
+
+	sendQueue chan tenantBatch
+	senderWG  sync.WaitGroup
+
+	// sendCtx bounds every sendBatch's HTTP roundtrip and retry backoff.
+	// Shutdown cancels it once its own ctx expires, so sendWorkers don't
+	// keep sleeping through backoff past the caller's deadline.
+	sendCtx    context.Context
+	sendCancel context.CancelFunc
+
+	maxRetries int
+	minBackoff time.Duration
+	maxBackoff time.Duration
+
+	deadLetter *deadLetterQueue
+
+	encoder Encoder
+
+	overflow     OverflowPolicy
+	dropCallback func(stream push.Stream)
+
+	stats clientStats
 }
 
 // Creates a loki client for v1 api with automatic batching.
@@ -82,26 +159,79 @@ func NewClient(endpoint string, o *ClientOptions) (Client, error) {
 	if o.BatchSize == 0 {
 		o.BatchSize = 5
 	}
+	if o.MinBackoff == 0 {
+		o.MinBackoff = 500 * time.Millisecond
+	}
+	if o.MaxBackoff == 0 {
+		o.MaxBackoff = time.Minute
+	}
+	if o.QueueSize == 0 {
+		o.QueueSize = 1000
+	}
+	if o.Encoder == nil {
+		if o.Compression == CompressionUnspecified {
+			// preserve the client's original defaults: snappy-compressed
+			// protobuf, uncompressed JSON.
+			if o.UseJSON {
+				o.Compression = CompressionNone
+			} else {
+				o.Compression = CompressionSnappy
+			}
+		}
+		o.Encoder = encoderFor(o.Compression)
+	}
+
+	sendCtx, sendCancel := context.WithCancel(context.Background())
 
 	client := clientImpl{
 		endpoint: endpoint,
 		closed:   false,
 
+		sendCtx:    sendCtx,
+		sendCancel: sendCancel,
+
 		maxBatchSize: int(o.BatchSize),
 		maxBatchWait: o.BatchWait,
 
 		quit:  make(chan struct{}),
 		flush: make(chan struct{}),
 
-		entries:     make(chan push.Stream, 1),
+		entries:     make(chan queuedStream, o.QueueSize),
 		errCallback: o.ErrorCallback,
 
+		tenantID:       o.TenantID,
+		tenantResolver: o.TenantResolver,
+
 		client:  o.HTTPClient,
 		useJSON: o.UseJSON,
 
+		sendQueue: make(chan tenantBatch, sendWorkers*2),
+
+		maxRetries: o.MaxRetries,
+		minBackoff: o.MinBackoff,
+		maxBackoff: o.MaxBackoff,
+
+		encoder: o.Encoder,
+
+		overflow:     o.OverflowPolicy,
+		dropCallback: o.DropCallback,
+
 		waitGroup: sync.WaitGroup{},
 	}
 
+	if o.DeadLetterDir != "" {
+		dl, err := newDeadLetterQueue(o.DeadLetterDir)
+		if err != nil {
+			return nil, fmt.Errorf("unable to open dead-letter queue: %w", err)
+		}
+		client.deadLetter = dl
+	}
+
+	client.senderWG.Add(sendWorkers)
+	for i := 0; i < sendWorkers; i++ {
+		go client.sendWorker()
+	}
+
 	client.waitGroup.Add(1)
 	go client.run()
 
@@ -113,7 +243,9 @@ func (c *clientImpl) Log(ts time.Time, msg string, lables, metadata map[string]s
 		return
 	}
 
-	c.entries <- push.Stream{
+	tenant := c.resolveTenant(lables)
+
+	stream := push.Stream{
 		Labels: labelsMapToString(lables),
 		Entries: []push.Entry{
 			{
@@ -123,6 +255,81 @@ func (c *clientImpl) Log(ts time.Time, msg string, lables, metadata map[string]s
 			},
 		},
 	}
+
+	c.enqueue(queuedStream{tenant: tenant, stream: stream})
+}
+
+// resolveTenant determines the X-Scope-OrgID a stream should be routed
+// under. TenantResolver, if set, takes precedence over the static TenantID;
+// it is given the same labels map used to build the stream, so it can strip
+// a routing label out of it.
+func (c *clientImpl) resolveTenant(labels map[string]string) string {
+	if c.tenantResolver != nil {
+		if tenant := c.tenantResolver(labels); tenant != "" {
+			return tenant
+		}
+	}
+	return c.tenantID
+}
+
+// enqueue applies the configured OverflowPolicy to push entry onto c.entries.
+func (c *clientImpl) enqueue(entry queuedStream) {
+	switch c.overflow.Kind {
+	case OverflowDropNewest:
+		select {
+		case c.entries <- entry:
+			c.stats.enqueued.Add(1)
+		default:
+			c.drop(entry)
+		}
+
+	case OverflowDropOldest:
+		select {
+		case c.entries <- entry:
+			c.stats.enqueued.Add(1)
+			return
+		default:
+		}
+
+		select {
+		case old := <-c.entries:
+			c.drop(old)
+		default:
+		}
+
+		select {
+		case c.entries <- entry:
+			c.stats.enqueued.Add(1)
+		default:
+			c.drop(entry)
+		}
+
+	case OverflowBlockWithTimeout:
+		timer := time.NewTimer(c.overflow.Timeout)
+		defer timer.Stop()
+
+		select {
+		case c.entries <- entry:
+			c.stats.enqueued.Add(1)
+		case <-timer.C:
+			c.drop(entry)
+		}
+
+	default: // OverflowBlock
+		c.entries <- entry
+		c.stats.enqueued.Add(1)
+	}
+}
+
+func (c *clientImpl) drop(entry queuedStream) {
+	c.stats.dropped.Add(1)
+	if c.dropCallback != nil {
+		c.dropCallback(entry.stream)
+	}
+}
+
+func (c *clientImpl) Stats() Stats {
+	return c.stats.snapshot()
 }
 
 func (c *clientImpl) Flush(ctx context.Context) {
@@ -141,110 +348,300 @@ func (c *clientImpl) Shutdown(ctx context.Context) {
 	close(c.quit)
 	close(c.flush)
 	close(c.entries)
-	c.waitGroup.Wait()
+
+	done := make(chan struct{})
+	go func() {
+		c.waitGroup.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		// ctx expired before the drain finished; cancel any in-flight
+		// retries so sendWorkers stop sleeping through backoff, and
+		// return without waiting further. The drain completes in the
+		// background.
+		c.sendCancel()
+	}
 }
 
+// batch accumulates entries for a single tenant, keyed by label string.
 type batch map[string][]push.Entry
 
+// queuedStream is a single log line queued on c.entries, tagged with the
+// tenant it was resolved to.
+type queuedStream struct {
+	tenant string
+	stream push.Stream
+}
+
+// tenantBatch is a batch destined for a single tenant, already marshaled and
+// encoded, queued on c.sendQueue. Each flush produces one tenantBatch per
+// tenant so a failure sending one tenant's batch can't hold up or poison
+// another's.
+type tenantBatch struct {
+	tenant          string
+	contentType     string
+	contentEncoding string
+	body            []byte
+}
+
 func (c *clientImpl) run() {
-	b := make(batch, c.maxBatchSize)
+	tenants := map[string]batch{}
+	streamCount := 0
 	wait := time.NewTicker(c.maxBatchWait)
 
-	var err error
+	defer func() {
+		close(c.sendQueue)
+		c.senderWG.Wait()
+		c.waitGroup.Done()
+	}()
+
+	flush := func() {
+		for tenant, b := range tenants {
+			c.dispatch(tenant, b)
+		}
+		tenants = map[string]batch{}
+		streamCount = 0
+	}
+
+	add := func(entry queuedStream) {
+		b, ok := tenants[entry.tenant]
+		if !ok {
+			b = make(batch, c.maxBatchSize)
+			tenants[entry.tenant] = b
+		}
+		if _, ok := b[entry.stream.Labels]; !ok {
+			streamCount++
+		}
+		b[entry.stream.Labels] = append(b[entry.stream.Labels], entry.stream.Entries...)
+	}
+
 LOOP:
 	for {
 		select {
 		case <-c.quit:
 			break LOOP
 		case entry := <-c.entries:
-			b[entry.Labels] = append(b[entry.Labels], entry.Entries...)
-			if len(b) >= int(c.maxBatchSize) {
-				err = c.send(b)
-				b = make(batch, c.maxBatchSize)
+			add(entry)
+			if streamCount >= c.maxBatchSize {
+				flush()
 				wait.Reset(c.maxBatchWait)
 			}
 
 		case <-wait.C:
-			if len(b) > 0 {
-				err = c.send(b)
-				b = make(batch, c.maxBatchSize)
+			if streamCount > 0 {
+				flush()
 			}
 			wait.Reset(c.maxBatchWait)
 		}
-		if err != nil && c.errCallback != nil {
-			c.errCallback(err)
-		}
 	}
 
 	for entry := range c.entries {
-		b[entry.Labels] = append(b[entry.Labels], entry.Entries...)
+		add(entry)
+	}
+	if streamCount > 0 {
+		flush()
+	}
+}
+
+// sendWorker drains batches off the send queue and ships them, retrying and
+// dead-lettering independently of the batching goroutine in run().
+func (c *clientImpl) sendWorker() {
+	defer c.senderWG.Done()
+	for tb := range c.sendQueue {
+		c.sendBatch(tb)
 	}
-	err = c.send(b)
+}
+
+// dispatch marshals and encodes a tenant's batch and hands it to a
+// sendWorker. The hand-off is non-blocking: if every sendWorker is busy
+// (most likely stuck in retry backoff against an unavailable Loki instance),
+// sendQueue being full must not block run(), since that would stop it from
+// draining c.entries too. In that case the batch is dead-lettered directly,
+// the same as a send that exhausted its retries.
+func (c *clientImpl) dispatch(tenant string, b batch) {
+	contentType, raw, err := marshalPushRequest(b, c.useJSON)
 	if err != nil {
-		c.errCallback(err)
+		if c.errCallback != nil {
+			c.errCallback(err)
+		}
+		return
 	}
-	c.waitGroup.Done()
+
+	scratch := bufferPool.Get().([]byte)
+	body := c.encoder.Encode(scratch, raw)
+	contentEncoding := c.encoder.ContentEncoding()
+
+	select {
+	case c.sendQueue <- tenantBatch{tenant: tenant, contentType: contentType, contentEncoding: contentEncoding, body: body}:
+		return
+	default:
+	}
+
+	c.stats.failed.Add(1)
+
+	if c.deadLetter != nil {
+		dlErr := c.deadLetter.write(deadLetterRecord{
+			Time:            time.Now(),
+			Tenant:          tenant,
+			ContentType:     contentType,
+			ContentEncoding: contentEncoding,
+			Body:            body,
+		})
+		if dlErr != nil && c.errCallback != nil {
+			c.errCallback(fmt.Errorf("dead-letter batch after send queue overflow: %w", dlErr))
+		}
+	} else if c.errCallback != nil {
+		c.errCallback(fmt.Errorf("send queue full, dropping batch for tenant %q", tenant))
+	}
+
+	bufferPool.Put(body[:0])
 }
 
-func (c *clientImpl) send(batch batch) error {
-	pushReq := push.PushRequest{
-		Streams: make([]push.Stream, 0, len(batch)),
+func (c *clientImpl) sendBatch(tb tenantBatch) {
+	defer bufferPool.Put(tb.body[:0])
+
+	c.stats.bytesInFlight.Add(uint64(len(tb.body)))
+	defer c.stats.bytesInFlight.Add(-uint64(len(tb.body)))
+
+	err := c.sendWithRetry(c.sendCtx, tb.tenant, tb.contentType, tb.contentEncoding, tb.body)
+	if err == nil {
+		c.stats.sent.Add(1)
+		return
 	}
-	for l, e := range batch {
-		pushReq.Streams = append(pushReq.Streams, push.Stream{
-			Labels:  l,
-			Entries: e,
+
+	c.stats.failed.Add(1)
+
+	if c.deadLetter != nil {
+		dlErr := c.deadLetter.write(deadLetterRecord{
+			Time:            time.Now(),
+			Tenant:          tb.tenant,
+			ContentType:     tb.contentType,
+			ContentEncoding: tb.contentEncoding,
+			Body:            tb.body,
 		})
+		if dlErr != nil && c.errCallback != nil {
+			c.errCallback(fmt.Errorf("dead-letter batch after send failure (%w): %w", err, dlErr))
+		}
+		return
 	}
 
-	var body []byte
-	var contentType, contentEncoding string
-	var err error
-	if c.useJSON {
-		contentType = "application/json"
-		body, err = json.Marshal(pushReq)
-		if err != nil {
+	if c.errCallback != nil {
+		c.errCallback(err)
+	}
+}
+
+// sendWithRetry performs the HTTP roundtrip, retrying retriable (429/5xx, or
+// network-level) failures with decorrelated jitter backoff up to maxRetries
+// times. A Retry-After header, when present, takes precedence over the
+// computed backoff if it is longer.
+func (c *clientImpl) sendWithRetry(ctx context.Context, tenant, contentType, contentEncoding string, body []byte) error {
+	backoff := newDecorrelatedJitterBackoff(c.minBackoff, c.maxBackoff)
+
+	var lastErr error
+	for attempt := 0; attempt <= c.maxRetries; attempt++ {
+		status, retryAfter, err := c.post(ctx, tenant, contentType, contentEncoding, body)
+		if err == nil {
+			return nil
+		}
+		lastErr = err
+
+		if status != 0 && !isRetriableStatus(status) {
 			return err
 		}
-	} else {
-		contentType = "application/x-protobuf"
-		contentEncoding = "snappy"
-		body, err = pushReq.Marshal()
-		if err != nil {
-			return fmt.Errorf("unable to marshal PushRequest: %w", err)
+		if attempt == c.maxRetries {
+			break
+		}
+		c.stats.retried.Add(1)
+
+		wait := backoff.Next()
+		if retryAfter > wait {
+			wait = retryAfter
+		}
+
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return ctx.Err()
 		}
-		body = s2.EncodeSnappy(nil, body)
 	}
 
-	req, err := http.NewRequest(http.MethodPost, c.endpoint, bytes.NewBuffer(body))
+	return lastErr
+}
+
+// post performs a single HTTP push request. status is 0 for network-level
+// errors (no response received), in which case the error is treated as
+// retriable by the caller.
+func (c *clientImpl) post(ctx context.Context, tenant, contentType, contentEncoding string, body []byte) (status int, retryAfter time.Duration, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint, bytes.NewReader(body))
 	if err != nil {
-		return err
+		return 0, 0, err
 	}
 	req.Header.Set("Content-Type", contentType)
 	if contentEncoding != "" {
 		req.Header.Set("Content-Encoding", contentEncoding)
 	}
+	if tenant != "" {
+		req.Header.Set("X-Scope-OrgID", tenant)
+	}
 
 	resp, err := c.client.Do(req)
 	if err != nil {
-		return fmt.Errorf("http request error: %w", err)
+		return 0, 0, fmt.Errorf("http request error: %w", err)
 	}
+	defer resp.Body.Close()
 
 	if resp.StatusCode != 204 {
-		body, err := io.ReadAll(resp.Body)
+		respBody, err := io.ReadAll(resp.Body)
 		if err != nil {
-			return fmt.Errorf("read response body: %w", err)
+			return resp.StatusCode, 0, fmt.Errorf("read response body: %w", err)
 		}
 
-		return fmt.Errorf("unexpected HTTP status code: %d, message: %s", resp.StatusCode, string(body))
+		return resp.StatusCode, parseRetryAfter(resp.Header), fmt.Errorf("unexpected HTTP status code: %d, message: %s", resp.StatusCode, string(respBody))
+	}
+
+	return resp.StatusCode, 0, nil
+}
+
+func (c *clientImpl) ReplayDeadLetter(ctx context.Context) error {
+	if c.deadLetter == nil {
+		return nil
+	}
+
+	return c.deadLetter.replay(func(rec deadLetterRecord) error {
+		return c.sendWithRetry(ctx, rec.Tenant, rec.ContentType, rec.ContentEncoding, rec.Body)
+	})
+}
+
+// marshalPushRequest serializes a batch into its uncompressed wire format.
+// Compression is applied separately by the caller via the client's Encoder.
+func marshalPushRequest(b batch, useJSON bool) (contentType string, body []byte, err error) {
+	pushReq := push.PushRequest{
+		Streams: make([]push.Stream, 0, len(b)),
+	}
+	for l, e := range b {
+		pushReq.Streams = append(pushReq.Streams, push.Stream{
+			Labels:  l,
+			Entries: e,
+		})
+	}
+
+	if useJSON {
+		body, err = json.Marshal(pushReq)
+		if err != nil {
+			return "", nil, err
+		}
+		return "application/json", body, nil
 	}
 
-	err = resp.Body.Close()
+	body, err = pushReq.Marshal()
 	if err != nil {
-		return fmt.Errorf("unable to close HTTP response body: %w", err)
+		return "", nil, fmt.Errorf("unable to marshal PushRequest: %w", err)
 	}
 
-	return nil
+	return "application/x-protobuf", body, nil
 }
 
 func mapToAdapter(ls map[string]string) []push.LabelAdapter {
@@ -0,0 +1,54 @@
+package loki
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+)
+
+type nopHTTPClient struct{}
+
+func (nopHTTPClient) Do(req *http.Request) (*http.Response, error) {
+	return &http.Response{
+		StatusCode: 204,
+		Body:       io.NopCloser(bytes.NewReader(nil)),
+	}, nil
+}
+
+func benchmarkClientLog(b *testing.B, queueSize uint, policy OverflowPolicy) {
+	client, err := NewClient("http://example.invalid/loki/api/v1/push", &ClientOptions{
+		HTTPClient:     nopHTTPClient{},
+		QueueSize:      queueSize,
+		OverflowPolicy: policy,
+		BatchSize:      100,
+		BatchWait:      time.Millisecond,
+	})
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer client.Shutdown(context.Background())
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			client.Log(time.Now(), "bench", map[string]string{"job": "bench"}, nil)
+		}
+	})
+}
+
+// BenchmarkClientLogQueueSize1 mirrors the client's original size-1 channel,
+// where every Log call blocks on the sender.
+func BenchmarkClientLogQueueSize1(b *testing.B) {
+	benchmarkClientLog(b, 1, BlockOverflow())
+}
+
+func BenchmarkClientLogQueueSize1024(b *testing.B) {
+	benchmarkClientLog(b, 1024, BlockOverflow())
+}
+
+func BenchmarkClientLogQueueSize1024DropNewest(b *testing.B) {
+	benchmarkClientLog(b, 1024, DropNewestOverflow())
+}
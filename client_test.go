@@ -0,0 +1,124 @@
+package loki
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grafana/loki/pkg/push"
+	"github.com/stretchr/testify/require"
+)
+
+// TestClientPartitionsByTenant covers run()/flush() splitting a single batch
+// window into one push request per tenant, each carrying its own
+// X-Scope-OrgID header, rather than merging streams from different tenants
+// into one request.
+func TestClientPartitionsByTenant(t *testing.T) {
+	require := require.New(t)
+
+	type gotRequest struct {
+		tenant string
+		lines  []string
+	}
+	var mu sync.Mutex
+	var reqs []gotRequest
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(err)
+
+		var req push.PushRequest
+		require.NoError(json.Unmarshal(body, &req))
+
+		var lines []string
+		for _, s := range req.Streams {
+			for _, e := range s.Entries {
+				lines = append(lines, e.Line)
+			}
+		}
+
+		mu.Lock()
+		reqs = append(reqs, gotRequest{tenant: r.Header.Get("X-Scope-OrgID"), lines: lines})
+		mu.Unlock()
+
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL, &ClientOptions{
+		UseJSON:   true,
+		BatchSize: 10,
+		BatchWait: time.Hour,
+		TenantResolver: func(labels map[string]string) string {
+			return labels["tenant"]
+		},
+	})
+	require.NoError(err)
+
+	client.Log(time.Now(), "from-a", map[string]string{"tenant": "a"}, nil)
+	client.Log(time.Now(), "from-b", map[string]string{"tenant": "b"}, nil)
+	client.Shutdown(context.Background())
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	require.Len(reqs, 2)
+	byTenant := map[string][]string{}
+	for _, r := range reqs {
+		byTenant[r.tenant] = r.lines
+	}
+	require.Equal([]string{"from-a"}, byTenant["a"])
+	require.Equal([]string{"from-b"}, byTenant["b"])
+}
+
+// TestClientTenantFailureDoesNotPoisonOthers covers the "failures for one
+// tenant must not poison batches for others" requirement: two tenants queued
+// in the same batch window, one of which always fails, must still result in
+// the other tenant's batch being sent successfully.
+func TestClientTenantFailureDoesNotPoisonOthers(t *testing.T) {
+	require := require.New(t)
+	dir := t.TempDir()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Scope-OrgID") == "bad" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	var errs []error
+	client, err := NewClient(server.URL, &ClientOptions{
+		UseJSON:       true,
+		BatchSize:     10,
+		BatchWait:     time.Hour,
+		DeadLetterDir: dir,
+		ErrorCallback: func(err error) { errs = append(errs, err) },
+		TenantResolver: func(labels map[string]string) string {
+			return labels["tenant"]
+		},
+	})
+	require.NoError(err)
+
+	client.Log(time.Now(), "good-line", map[string]string{"tenant": "good"}, nil)
+	client.Log(time.Now(), "bad-line", map[string]string{"tenant": "bad"}, nil)
+	client.Shutdown(context.Background())
+
+	// The failure is dead-lettered, not surfaced through ErrorCallback.
+	require.Empty(errs)
+
+	stats := client.Stats()
+	require.Equal(uint64(1), stats.Sent)
+	require.Equal(uint64(1), stats.Failed)
+
+	segments, err := readManifest(filepath.Join(dir, deadLetterManifestName))
+	require.NoError(err)
+	require.Len(segments, 1)
+}
@@ -0,0 +1,96 @@
+package loki
+
+import (
+	"bytes"
+	"compress/gzip"
+	"sync"
+
+	"github.com/klauspost/compress/s2"
+	"github.com/klauspost/compress/zstd"
+)
+
+// Compression selects a built-in Encoder for ClientOptions.Compression. The
+// zero value, CompressionUnspecified, is distinct from CompressionNone so
+// NewClient can tell "not set, apply the default" apart from "explicitly
+// asked for no compression".
+type Compression int
+
+const (
+	CompressionUnspecified Compression = iota
+	CompressionNone
+	CompressionSnappy
+	CompressionGzip
+	CompressionZstd
+)
+
+// Encoder compresses a push request body. A client calls Encode only from its
+// single batching goroutine, one batch at a time, before the encoded body is
+// handed to the send workers.
+type Encoder interface {
+	// Encode compresses src, appending to dst (reusing its backing array
+	// when it has enough capacity), and returns the result.
+	Encode(dst, src []byte) []byte
+	// ContentEncoding is the HTTP Content-Encoding header value for this
+	// codec, or "" for no compression.
+	ContentEncoding() string
+}
+
+func encoderFor(c Compression) Encoder {
+	switch c {
+	case CompressionSnappy:
+		return snappyEncoder{}
+	case CompressionGzip:
+		return gzipEncoder{}
+	case CompressionZstd:
+		return zstdEncoder{}
+	default:
+		return noneEncoder{}
+	}
+}
+
+// bufferPool holds scratch buffers used as the Encode destination, so large
+// batches don't allocate a fresh []byte on every send.
+var bufferPool = sync.Pool{
+	New: func() any {
+		return make([]byte, 0, 4096)
+	},
+}
+
+type noneEncoder struct{}
+
+func (noneEncoder) Encode(dst, src []byte) []byte { return append(dst[:0], src...) }
+func (noneEncoder) ContentEncoding() string       { return "" }
+
+type snappyEncoder struct{}
+
+func (snappyEncoder) Encode(dst, src []byte) []byte { return s2.EncodeSnappy(dst[:0], src) }
+func (snappyEncoder) ContentEncoding() string       { return "snappy" }
+
+type gzipEncoder struct{}
+
+func (gzipEncoder) Encode(dst, src []byte) []byte {
+	buf := bytes.NewBuffer(dst[:0])
+	w := gzip.NewWriter(buf)
+	_, _ = w.Write(src)
+	_ = w.Close()
+	return buf.Bytes()
+}
+func (gzipEncoder) ContentEncoding() string { return "gzip" }
+
+// zstdEncoderPool reuses *zstd.Encoder instances, since constructing one is
+// too costly to do on every send.
+var zstdEncoderPool = sync.Pool{
+	New: func() any {
+		enc, _ := zstd.NewWriter(nil)
+		return enc
+	},
+}
+
+type zstdEncoder struct{}
+
+func (zstdEncoder) Encode(dst, src []byte) []byte {
+	enc := zstdEncoderPool.Get().(*zstd.Encoder)
+	defer zstdEncoderPool.Put(enc)
+	return enc.EncodeAll(src, dst[:0])
+}
+func (zstdEncoder) ContentEncoding() string { return "zstd" }
@@ -0,0 +1,109 @@
+package loki
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/grafana/loki/pkg/push"
+	"github.com/klauspost/compress/s2"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/require"
+)
+
+func decode(t *testing.T, contentEncoding string, body []byte) []byte {
+	t.Helper()
+	switch contentEncoding {
+	case "":
+		return body
+	case "snappy":
+		decoded, err := s2.Decode(nil, body)
+		require.NoError(t, err)
+		return decoded
+	case "gzip":
+		r, err := gzip.NewReader(bytes.NewReader(body))
+		require.NoError(t, err)
+		decoded, err := io.ReadAll(r)
+		require.NoError(t, err)
+		return decoded
+	case "zstd":
+		dec, err := zstd.NewReader(bytes.NewReader(body))
+		require.NoError(t, err)
+		defer dec.Close()
+		decoded, err := io.ReadAll(dec)
+		require.NoError(t, err)
+		return decoded
+	default:
+		t.Fatalf("unexpected Content-Encoding: %s", contentEncoding)
+		return nil
+	}
+}
+
+func TestCompressionMatrix(t *testing.T) {
+	matrix := []struct {
+		useJSON         bool
+		compression     Compression
+		wantContentType string
+		wantEncoding    string
+	}{
+		{false, CompressionNone, "application/x-protobuf", ""},
+		{false, CompressionSnappy, "application/x-protobuf", "snappy"},
+		{false, CompressionGzip, "application/x-protobuf", "gzip"},
+		{false, CompressionZstd, "application/x-protobuf", "zstd"},
+		{true, CompressionNone, "application/json", ""},
+		{true, CompressionSnappy, "application/json", "snappy"},
+		{true, CompressionGzip, "application/json", "gzip"},
+		{true, CompressionZstd, "application/json", "zstd"},
+	}
+
+	for _, tc := range matrix {
+		tc := tc
+		t.Run(tc.wantContentType+"/"+tc.wantEncoding, func(t *testing.T) {
+			require := require.New(t)
+
+			var gotContentType, gotEncoding string
+			var gotReq push.PushRequest
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotContentType = r.Header.Get("Content-Type")
+				gotEncoding = r.Header.Get("Content-Encoding")
+
+				body, err := io.ReadAll(r.Body)
+				require.NoError(err)
+				body = decode(t, gotEncoding, body)
+
+				if gotContentType == "application/json" {
+					require.NoError(json.Unmarshal(body, &gotReq))
+				} else {
+					require.NoError(gotReq.Unmarshal(body))
+				}
+
+				w.WriteHeader(http.StatusNoContent)
+			}))
+			defer server.Close()
+
+			client, err := NewClient(server.URL, &ClientOptions{
+				UseJSON:     tc.useJSON,
+				Compression: tc.compression,
+				BatchSize:   1,
+				BatchWait:   time.Hour,
+			})
+			require.NoError(err)
+
+			client.Log(time.Now(), "hello", map[string]string{"job": "test"}, nil)
+			client.Shutdown(context.Background())
+
+			require.Equal(tc.wantContentType, gotContentType)
+			require.Equal(tc.wantEncoding, gotEncoding)
+			require.Len(gotReq.Streams, 1)
+			require.Len(gotReq.Streams[0].Entries, 1)
+			require.Equal("hello", gotReq.Streams[0].Entries[0].Line)
+		})
+	}
+}
@@ -0,0 +1,240 @@
+package loki
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// deadLetterSegmentSize is the approximate max size of a single segment file
+// before a new one is rotated in.
+const deadLetterSegmentSize = 64 << 20 // 64MiB
+
+const deadLetterManifestName = "manifest"
+
+// deadLetterRecord is a single failed push request, persisted verbatim so it
+// can be replayed later with the same wire format it was built with.
+type deadLetterRecord struct {
+	Time            time.Time `json:"time"`
+	Tenant          string    `json:"tenant,omitempty"`
+	ContentType     string    `json:"content_type"`
+	ContentEncoding string    `json:"content_encoding"`
+	Body            []byte    `json:"body"`
+}
+
+// deadLetterQueue persists batches that exhausted their retries as
+// newline-delimited JSON records in append-only segment files under dir, and
+// replays them on request. A manifest file tracks segment order so replay can
+// resume across process restarts.
+type deadLetterQueue struct {
+	dir string
+	// segmentSize is the rotation threshold; a field rather than using
+	// deadLetterSegmentSize directly so tests can rotate without writing
+	// tens of megabytes.
+	segmentSize int64
+
+	mu  sync.Mutex
+	f   *os.File
+	err error
+}
+
+func newDeadLetterQueue(dir string) (*deadLetterQueue, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create dead-letter dir: %w", err)
+	}
+	return &deadLetterQueue{dir: dir, segmentSize: deadLetterSegmentSize}, nil
+}
+
+func (q *deadLetterQueue) write(rec deadLetterRecord) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.f == nil {
+		if err := q.rotateLocked(); err != nil {
+			return err
+		}
+	}
+
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("marshal dead-letter record: %w", err)
+	}
+	line = append(line, '\n')
+
+	if _, err := q.f.Write(line); err != nil {
+		return fmt.Errorf("write dead-letter record: %w", err)
+	}
+
+	if info, err := q.f.Stat(); err == nil && info.Size() >= q.segmentSize {
+		q.f.Close()
+		q.f = nil
+	}
+
+	return nil
+}
+
+func (q *deadLetterQueue) rotateLocked() error {
+	name := fmt.Sprintf("%d.seg", time.Now().UnixNano())
+
+	f, err := os.OpenFile(filepath.Join(q.dir, name), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("create dead-letter segment: %w", err)
+	}
+	q.f = f
+
+	manifest, err := os.OpenFile(filepath.Join(q.dir, deadLetterManifestName), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("open dead-letter manifest: %w", err)
+	}
+	defer manifest.Close()
+
+	if _, err := manifest.WriteString(name + "\n"); err != nil {
+		return fmt.Errorf("write dead-letter manifest: %w", err)
+	}
+
+	return nil
+}
+
+// replay re-sends every record in every segment listed in the manifest, in
+// order, calling send for each. A fully replayed segment is deleted and
+// dropped from the manifest. Replay stops at the first error, leaving that
+// segment (and any after it) in place so a later call can resume.
+//
+// replay only holds q.mu to snapshot the segment list up front and to
+// reconcile the manifest afterward; it does NOT hold it across send, since
+// send may retry over the network for a long time (MaxRetries * MaxBackoff),
+// and write() is called inline from the client's batching goroutine -
+// blocking it for that long would stall the whole client. Because the lock
+// is released during send, write() may rotate in new segments while replay
+// is in flight; replay reconciles by diffing against the manifest as it
+// stands when replay finishes, removing only the segments it actually
+// replayed, rather than overwriting wholesale from its stale snapshot.
+func (q *deadLetterQueue) replay(send func(deadLetterRecord) error) error {
+	manifestPath := filepath.Join(q.dir, deadLetterManifestName)
+
+	q.mu.Lock()
+	if q.f != nil {
+		// Don't replay into the segment we might still be appending to.
+		q.f.Close()
+		q.f = nil
+	}
+	segments, err := readManifest(manifestPath)
+	q.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	var done []string
+	var replayErr error
+	for _, segment := range segments {
+		if err := q.replaySegment(segment, send); err != nil {
+			replayErr = err
+			break
+		}
+		done = append(done, segment)
+	}
+
+	if len(done) == 0 {
+		return replayErr
+	}
+
+	doneSet := make(map[string]struct{}, len(done))
+	for _, segment := range done {
+		doneSet[segment] = struct{}{}
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	current, err := readManifest(manifestPath)
+	if err != nil {
+		return err
+	}
+
+	remaining := current[:0]
+	for _, segment := range current {
+		if _, ok := doneSet[segment]; !ok {
+			remaining = append(remaining, segment)
+		}
+	}
+
+	if err := writeManifest(manifestPath, remaining); err != nil {
+		return err
+	}
+	return replayErr
+}
+
+func (q *deadLetterQueue) replaySegment(segment string, send func(deadLetterRecord) error) error {
+	path := filepath.Join(q.dir, segment)
+
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("open dead-letter segment %s: %w", segment, err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), deadLetterSegmentSize)
+	for scanner.Scan() {
+		var rec deadLetterRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			return fmt.Errorf("decode dead-letter record in %s: %w", segment, err)
+		}
+		if err := send(rec); err != nil {
+			return fmt.Errorf("replay dead-letter record from %s: %w", segment, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("read dead-letter segment %s: %w", segment, err)
+	}
+
+	return os.Remove(path)
+}
+
+func readManifest(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open dead-letter manifest: %w", err)
+	}
+	defer f.Close()
+
+	var segments []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if line := scanner.Text(); line != "" {
+			segments = append(segments, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read dead-letter manifest: %w", err)
+	}
+
+	return segments, nil
+}
+
+func writeManifest(path string, segments []string) error {
+	if len(segments) == 0 {
+		err := os.Remove(path)
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	var buf []byte
+	for _, segment := range segments {
+		buf = append(buf, segment+"\n"...)
+	}
+
+	return os.WriteFile(path, buf, 0o644)
+}
@@ -0,0 +1,172 @@
+package loki
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/grafana/loki/pkg/push"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeadLetterQueueRotatesSegments(t *testing.T) {
+	require := require.New(t)
+	dir := t.TempDir()
+
+	q := &deadLetterQueue{dir: dir, segmentSize: 40}
+	rec := deadLetterRecord{Time: time.Now(), ContentType: "application/json", Body: []byte("0123456789012345678901234567890123456789")}
+
+	require.NoError(q.write(rec))
+	require.NoError(q.write(rec))
+
+	segments, err := readManifest(filepath.Join(dir, deadLetterManifestName))
+	require.NoError(err)
+	require.Len(segments, 2)
+
+	for _, seg := range segments {
+		info, err := os.Stat(filepath.Join(dir, seg))
+		require.NoError(err)
+		require.GreaterOrEqual(info.Size(), int64(40))
+	}
+}
+
+func TestDeadLetterQueueReplayResumesAfterFailure(t *testing.T) {
+	require := require.New(t)
+	dir := t.TempDir()
+
+	q := &deadLetterQueue{dir: dir, segmentSize: 40}
+	rec := func(line string) deadLetterRecord {
+		return deadLetterRecord{Time: time.Now(), ContentType: "application/json", Body: []byte(line)}
+	}
+
+	require.NoError(q.write(rec("first-record-padded-to-force-rotate")))
+	require.NoError(q.write(rec("second-record-padded-to-force-rotate")))
+	require.NoError(q.write(rec("third-record-padded-to-force-rotate")))
+
+	segmentsBefore, err := readManifest(filepath.Join(dir, deadLetterManifestName))
+	require.NoError(err)
+	require.Len(segmentsBefore, 3)
+
+	var sent []string
+	replayErr := q.replay(func(r deadLetterRecord) error {
+		sent = append(sent, string(r.Body))
+		if string(r.Body) == "second-record-padded-to-force-rotate" {
+			return errors.New("boom")
+		}
+		return nil
+	})
+	// replay stops after the failing record without propagating it; the
+	// caller learns about the incomplete replay from the manifest still
+	// listing unreplayed segments, and can retry later.
+	require.NoError(replayErr)
+	require.Equal([]string{"first-record-padded-to-force-rotate", "second-record-padded-to-force-rotate"}, sent)
+
+	// The first segment was fully replayed and dropped from the manifest;
+	// the second (where the failure happened) and third are left in place
+	// for a later retry.
+	remaining, err := readManifest(filepath.Join(dir, deadLetterManifestName))
+	require.NoError(err)
+	require.Len(remaining, 2)
+
+	sent = nil
+	require.NoError(q.replay(func(r deadLetterRecord) error {
+		sent = append(sent, string(r.Body))
+		return nil
+	}))
+	require.Equal([]string{"second-record-padded-to-force-rotate", "third-record-padded-to-force-rotate"}, sent)
+
+	_, err = os.Stat(filepath.Join(dir, deadLetterManifestName))
+	require.True(os.IsNotExist(err))
+}
+
+func TestDeadLetterQueueReplayDoesNotOrphanConcurrentWrite(t *testing.T) {
+	require := require.New(t)
+	dir := t.TempDir()
+
+	q := &deadLetterQueue{dir: dir, segmentSize: 40}
+	rec := func(line string) deadLetterRecord {
+		return deadLetterRecord{Time: time.Now(), ContentType: "application/json", Body: []byte(line)}
+	}
+
+	require.NoError(q.write(rec("first-record-padded-to-force-rotate")))
+
+	// Simulate another tenant's batch (e.g. still failing in sendBatch on a
+	// live client) rotating in a new segment while this replay is in
+	// flight. replay must not hold q.mu across send, so this does not
+	// block, and the new segment must not be clobbered by replay's final
+	// manifest reconciliation.
+	require.NoError(q.replay(func(r deadLetterRecord) error {
+		require.NoError(q.write(rec("concurrent-record-padded-to-force-rotate")))
+		return nil
+	}))
+
+	// The segment written during replay must still be tracked, not
+	// clobbered by replay's final manifest write.
+	remaining, err := readManifest(filepath.Join(dir, deadLetterManifestName))
+	require.NoError(err)
+	require.Len(remaining, 1)
+
+	var sent []string
+	require.NoError(q.replay(func(r deadLetterRecord) error {
+		sent = append(sent, string(r.Body))
+		return nil
+	}))
+	require.Equal([]string{"concurrent-record-padded-to-force-rotate"}, sent)
+}
+
+func TestClientReplayDeadLetterEndToEnd(t *testing.T) {
+	require := require.New(t)
+	dir := t.TempDir()
+
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer down.Close()
+
+	var errs []error
+	client, err := NewClient(down.URL, &ClientOptions{
+		BatchSize:     1,
+		BatchWait:     time.Hour,
+		UseJSON:       true,
+		DeadLetterDir: dir,
+		ErrorCallback: func(err error) { errs = append(errs, err) },
+	})
+	require.NoError(err)
+
+	client.Log(time.Now(), "boom", map[string]string{"job": "x"}, nil)
+	client.Shutdown(context.Background())
+	require.Empty(errs) // dead-lettered rather than surfaced as an error
+
+	var received []string
+	up := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(err)
+
+		var req push.PushRequest
+		require.NoError(json.Unmarshal(body, &req))
+		for _, s := range req.Streams {
+			for _, e := range s.Entries {
+				received = append(received, e.Line)
+			}
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer up.Close()
+
+	replayer, err := NewClient(up.URL, &ClientOptions{DeadLetterDir: dir})
+	require.NoError(err)
+	defer replayer.Shutdown(context.Background())
+
+	require.NoError(replayer.ReplayDeadLetter(context.Background()))
+	require.Equal([]string{"boom"}, received)
+
+	_, err = os.Stat(filepath.Join(dir, deadLetterManifestName))
+	require.True(os.IsNotExist(err))
+}
@@ -0,0 +1,38 @@
+package loki
+
+import "time"
+
+// OverflowKind selects what a clientImpl does with a Log call when the
+// ingestion queue (ClientOptions.QueueSize) is full.
+type OverflowKind int
+
+const (
+	// OverflowBlock blocks the caller until space is available. This is the
+	// default and matches the client's original size-1-channel behavior.
+	OverflowBlock OverflowKind = iota
+	// OverflowDropNewest discards the record that was about to be enqueued.
+	OverflowDropNewest
+	// OverflowDropOldest discards the oldest queued record to make room.
+	OverflowDropOldest
+	// OverflowBlockWithTimeout blocks up to OverflowPolicy.Timeout, then
+	// falls back to dropping the record that was about to be enqueued.
+	OverflowBlockWithTimeout
+)
+
+// OverflowPolicy describes what to do when the ingestion queue is full. The
+// zero value is OverflowBlock. Use the constructors below rather than
+// building this struct directly.
+type OverflowPolicy struct {
+	Kind    OverflowKind
+	Timeout time.Duration
+}
+
+func BlockOverflow() OverflowPolicy { return OverflowPolicy{Kind: OverflowBlock} }
+
+func DropNewestOverflow() OverflowPolicy { return OverflowPolicy{Kind: OverflowDropNewest} }
+
+func DropOldestOverflow() OverflowPolicy { return OverflowPolicy{Kind: OverflowDropOldest} }
+
+func BlockWithTimeoutOverflow(timeout time.Duration) OverflowPolicy {
+	return OverflowPolicy{Kind: OverflowBlockWithTimeout, Timeout: timeout}
+}
@@ -0,0 +1,90 @@
+package loki
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/loki/pkg/push"
+	"github.com/stretchr/testify/require"
+)
+
+func stream(labels string) queuedStream {
+	return queuedStream{stream: push.Stream{Labels: labels}}
+}
+
+func TestEnqueueDropNewest(t *testing.T) {
+	require := require.New(t)
+
+	var dropped []push.Stream
+	c := &clientImpl{
+		entries:      make(chan queuedStream, 1),
+		overflow:     DropNewestOverflow(),
+		dropCallback: func(s push.Stream) { dropped = append(dropped, s) },
+	}
+
+	c.enqueue(stream("{a=\"1\"}"))
+	c.enqueue(stream("{a=\"2\"}"))
+
+	require.Equal(uint64(1), c.stats.enqueued.Load())
+	require.Equal(uint64(1), c.stats.dropped.Load())
+	require.Len(dropped, 1)
+	require.Equal("{a=\"2\"}", dropped[0].Labels)
+}
+
+func TestEnqueueDropOldest(t *testing.T) {
+	require := require.New(t)
+
+	var dropped []push.Stream
+	c := &clientImpl{
+		entries:      make(chan queuedStream, 1),
+		overflow:     DropOldestOverflow(),
+		dropCallback: func(s push.Stream) { dropped = append(dropped, s) },
+	}
+
+	c.enqueue(stream("{a=\"1\"}"))
+	c.enqueue(stream("{a=\"2\"}"))
+
+	require.Equal(uint64(2), c.stats.enqueued.Load())
+	require.Equal(uint64(1), c.stats.dropped.Load())
+	require.Len(dropped, 1)
+	require.Equal("{a=\"1\"}", dropped[0].Labels)
+
+	queued := <-c.entries
+	require.Equal("{a=\"2\"}", queued.stream.Labels)
+}
+
+func TestEnqueueBlockWithTimeout(t *testing.T) {
+	require := require.New(t)
+
+	var dropped []push.Stream
+	c := &clientImpl{
+		entries:      make(chan queuedStream, 1),
+		overflow:     BlockWithTimeoutOverflow(10 * time.Millisecond),
+		dropCallback: func(s push.Stream) { dropped = append(dropped, s) },
+	}
+
+	c.enqueue(stream("{a=\"1\"}"))
+	c.enqueue(stream("{a=\"2\"}"))
+
+	require.Equal(uint64(1), c.stats.enqueued.Load())
+	require.Len(dropped, 1)
+}
+
+func TestResolveTenant(t *testing.T) {
+	require := require.New(t)
+
+	c := &clientImpl{tenantID: "default"}
+	require.Equal("default", c.resolveTenant(map[string]string{}))
+
+	c.tenantResolver = func(labels map[string]string) string {
+		tenant := labels["tenant"]
+		delete(labels, "tenant")
+		return tenant
+	}
+
+	labels := map[string]string{"tenant": "acme", "job": "api"}
+	require.Equal("acme", c.resolveTenant(labels))
+	require.Equal(map[string]string{"job": "api"}, labels)
+
+	require.Equal("default", c.resolveTenant(map[string]string{"job": "api"}))
+}
@@ -0,0 +1,74 @@
+// Package lokiprometheus exposes a loki.Client's Stats() as a
+// prometheus.Collector.
+package lokiprometheus
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/royalcat/loki"
+)
+
+// StatsProvider is satisfied by loki.Client.
+type StatsProvider interface {
+	Stats() loki.Stats
+}
+
+var _ prometheus.Collector = (*Collector)(nil)
+
+// Collector adapts a loki.Client's Stats() into Prometheus metrics. Register
+// it with a prometheus.Registry to expose ingestion/delivery counters.
+type Collector struct {
+	provider StatsProvider
+
+	enqueued      *prometheus.Desc
+	dropped       *prometheus.Desc
+	sent          *prometheus.Desc
+	failed        *prometheus.Desc
+	retried       *prometheus.Desc
+	bytesInFlight *prometheus.Desc
+}
+
+// NewCollector builds a Collector reading from provider on every scrape.
+func NewCollector(provider StatsProvider) *Collector {
+	return &Collector{
+		provider: provider,
+
+		enqueued: prometheus.NewDesc(
+			"loki_client_enqueued_total", "Total number of log records enqueued.", nil, nil,
+		),
+		dropped: prometheus.NewDesc(
+			"loki_client_dropped_total", "Total number of log records dropped due to a full queue.", nil, nil,
+		),
+		sent: prometheus.NewDesc(
+			"loki_client_sent_total", "Total number of batches sent successfully.", nil, nil,
+		),
+		failed: prometheus.NewDesc(
+			"loki_client_failed_total", "Total number of batches that failed to send after all retries.", nil, nil,
+		),
+		retried: prometheus.NewDesc(
+			"loki_client_retried_total", "Total number of retry attempts made while sending batches.", nil, nil,
+		),
+		bytesInFlight: prometheus.NewDesc(
+			"loki_client_bytes_in_flight", "Bytes of encoded batches currently being sent.", nil, nil,
+		),
+	}
+}
+
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.enqueued
+	ch <- c.dropped
+	ch <- c.sent
+	ch <- c.failed
+	ch <- c.retried
+	ch <- c.bytesInFlight
+}
+
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.provider.Stats()
+
+	ch <- prometheus.MustNewConstMetric(c.enqueued, prometheus.CounterValue, float64(stats.Enqueued))
+	ch <- prometheus.MustNewConstMetric(c.dropped, prometheus.CounterValue, float64(stats.Dropped))
+	ch <- prometheus.MustNewConstMetric(c.sent, prometheus.CounterValue, float64(stats.Sent))
+	ch <- prometheus.MustNewConstMetric(c.failed, prometheus.CounterValue, float64(stats.Failed))
+	ch <- prometheus.MustNewConstMetric(c.retried, prometheus.CounterValue, float64(stats.Retried))
+	ch <- prometheus.MustNewConstMetric(c.bytesInFlight, prometheus.GaugeValue, float64(stats.BytesInFlight))
+}
@@ -0,0 +1,63 @@
+package lokiprometheus_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/royalcat/loki"
+	lokiprometheus "github.com/royalcat/loki/prometheus"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeStatsProvider struct {
+	stats loki.Stats
+}
+
+func (f fakeStatsProvider) Stats() loki.Stats {
+	return f.stats
+}
+
+func TestCollectorDescribe(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	c := lokiprometheus.NewCollector(fakeStatsProvider{})
+	require.Equal(6, testutil.CollectAndCount(c))
+}
+
+func TestCollectorCollect(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	c := lokiprometheus.NewCollector(fakeStatsProvider{stats: loki.Stats{
+		Enqueued:      10,
+		Dropped:       1,
+		Sent:          8,
+		Failed:        2,
+		Retried:       3,
+		BytesInFlight: 1024,
+	}})
+
+	want := `
+		# HELP loki_client_bytes_in_flight Bytes of encoded batches currently being sent.
+		# TYPE loki_client_bytes_in_flight gauge
+		loki_client_bytes_in_flight 1024
+		# HELP loki_client_dropped_total Total number of log records dropped due to a full queue.
+		# TYPE loki_client_dropped_total counter
+		loki_client_dropped_total 1
+		# HELP loki_client_enqueued_total Total number of log records enqueued.
+		# TYPE loki_client_enqueued_total counter
+		loki_client_enqueued_total 10
+		# HELP loki_client_failed_total Total number of batches that failed to send after all retries.
+		# TYPE loki_client_failed_total counter
+		loki_client_failed_total 2
+		# HELP loki_client_retried_total Total number of retry attempts made while sending batches.
+		# TYPE loki_client_retried_total counter
+		loki_client_retried_total 3
+		# HELP loki_client_sent_total Total number of batches sent successfully.
+		# TYPE loki_client_sent_total counter
+		loki_client_sent_total 8
+	`
+	require.NoError(testutil.CollectAndCompare(c, strings.NewReader(want)))
+}
@@ -0,0 +1,58 @@
+package loki
+
+import (
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// isRetriableStatus reports whether a Loki push response status should be
+// retried. 429 (rate limited) and 5xx are transient; other 4xx are terminal
+// client errors (bad request, unauthorized, ...) that won't succeed on retry.
+func isRetriableStatus(status int) bool {
+	return status == http.StatusTooManyRequests || status >= 500
+}
+
+// parseRetryAfter parses a Retry-After response header expressed as a number
+// of seconds. HTTP-date values and a missing header both yield 0.
+func parseRetryAfter(h http.Header) time.Duration {
+	v := h.Get("Retry-After")
+	if v == "" {
+		return 0
+	}
+
+	seconds, err := strconv.Atoi(v)
+	if err != nil || seconds < 0 {
+		return 0
+	}
+
+	return time.Duration(seconds) * time.Second
+}
+
+// decorrelatedJitterBackoff implements the "decorrelated jitter" algorithm
+// recommended in https://aws.amazon.com/blogs/architecture/exponential-backoff-and-jitter/:
+// sleep = min(max, random_between(min, prevSleep*3))
+type decorrelatedJitterBackoff struct {
+	min, max time.Duration
+	prev     time.Duration
+}
+
+func newDecorrelatedJitterBackoff(min, max time.Duration) *decorrelatedJitterBackoff {
+	return &decorrelatedJitterBackoff{min: min, max: max, prev: min}
+}
+
+func (b *decorrelatedJitterBackoff) Next() time.Duration {
+	upper := b.prev * 3
+	if upper > b.max {
+		upper = b.max
+	}
+	if upper <= b.min {
+		b.prev = b.min
+		return b.min
+	}
+
+	next := b.min + time.Duration(rand.Int63n(int64(upper-b.min)))
+	b.prev = next
+	return next
+}
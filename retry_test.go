@@ -0,0 +1,48 @@
+package loki
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsRetriableStatus(t *testing.T) {
+	require := require.New(t)
+
+	require.True(isRetriableStatus(http.StatusTooManyRequests))
+	require.True(isRetriableStatus(http.StatusInternalServerError))
+	require.True(isRetriableStatus(http.StatusServiceUnavailable))
+
+	require.False(isRetriableStatus(http.StatusBadRequest))
+	require.False(isRetriableStatus(http.StatusUnauthorized))
+	require.False(isRetriableStatus(http.StatusOK))
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	require := require.New(t)
+
+	h := http.Header{}
+	require.Equal(time.Duration(0), parseRetryAfter(h))
+
+	h.Set("Retry-After", "5")
+	require.Equal(5*time.Second, parseRetryAfter(h))
+
+	h.Set("Retry-After", "not-a-number")
+	require.Equal(time.Duration(0), parseRetryAfter(h))
+}
+
+func TestDecorrelatedJitterBackoffStaysWithinBounds(t *testing.T) {
+	require := require.New(t)
+
+	min := 100 * time.Millisecond
+	max := time.Second
+	b := newDecorrelatedJitterBackoff(min, max)
+
+	for i := 0; i < 100; i++ {
+		d := b.Next()
+		require.GreaterOrEqual(d, min)
+		require.LessOrEqual(d, max)
+	}
+}
@@ -0,0 +1,63 @@
+package lokislog
+
+import (
+	"context"
+	"maps"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ContextExtractor pulls additional labels and metadata out of a
+// context.Context on every record handled by a LokiHandler. Keep labels
+// low-cardinality; anything else (correlation ids, trace ids, request ids...)
+// belongs in metadata.
+type ContextExtractor func(ctx context.Context) (labels, metadata map[string]string)
+
+// CombineContextExtractors merges the labels/metadata of several
+// ContextExtractors into one, in order. Later extractors overwrite keys set
+// by earlier ones.
+func CombineContextExtractors(extractors ...ContextExtractor) ContextExtractor {
+	return func(ctx context.Context) (labels, metadata map[string]string) {
+		labels = map[string]string{}
+		metadata = map[string]string{}
+		for _, extract := range extractors {
+			l, m := extract(ctx)
+			maps.Copy(labels, l)
+			maps.Copy(metadata, m)
+		}
+		return labels, metadata
+	}
+}
+
+// TraceContextExtractor is a ContextExtractor that pulls the current
+// OpenTelemetry trace_id/span_id into structured metadata. These are always
+// routed to metadata, never labels, since trace/span ids would blow up label
+// cardinality in Loki.
+func TraceContextExtractor(ctx context.Context) (labels, metadata map[string]string) {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return nil, nil
+	}
+
+	return nil, map[string]string{
+		"trace_id": sc.TraceID().String(),
+		"span_id":  sc.SpanID().String(),
+	}
+}
+
+type contextLabelsKey struct{}
+
+// WithContextLabels stores an immutable copy of labels in ctx, to be read
+// back by ContextLabelsExtractor. Useful for low-cardinality values such as
+// tenant ids that should end up as labels without threading them through
+// every slog.Logger call.
+func WithContextLabels(ctx context.Context, labels map[string]string) context.Context {
+	return context.WithValue(ctx, contextLabelsKey{}, maps.Clone(labels))
+}
+
+// ContextLabelsExtractor is a ContextExtractor that reads back the labels
+// stored by WithContextLabels.
+func ContextLabelsExtractor(ctx context.Context) (labels, metadata map[string]string) {
+	labels, _ = ctx.Value(contextLabelsKey{}).(map[string]string)
+	return labels, nil
+}
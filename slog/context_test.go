@@ -0,0 +1,67 @@
+package lokislog_test
+
+import (
+	"context"
+	"testing"
+
+	slogloki "github.com/royalcat/loki/slog"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTraceContextExtractor(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: trace.TraceID{1},
+		SpanID:  trace.SpanID{2},
+	})
+	ctx := trace.ContextWithSpanContext(context.Background(), sc)
+
+	labels, metadata := slogloki.TraceContextExtractor(ctx)
+	require.Nil(labels)
+	require.Equal(map[string]string{
+		"trace_id": sc.TraceID().String(),
+		"span_id":  sc.SpanID().String(),
+	}, metadata)
+}
+
+func TestTraceContextExtractorNoSpan(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	labels, metadata := slogloki.TraceContextExtractor(context.Background())
+	require.Nil(labels)
+	require.Nil(metadata)
+}
+
+func TestCombineContextExtractors(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	first := func(context.Context) (labels, metadata map[string]string) {
+		return map[string]string{"k": "first"}, map[string]string{"m": "first"}
+	}
+	second := func(context.Context) (labels, metadata map[string]string) {
+		return map[string]string{"k": "second"}, nil
+	}
+
+	combined := slogloki.CombineContextExtractors(first, second)
+	labels, metadata := combined(context.Background())
+
+	// Later extractors overwrite keys set by earlier ones.
+	require.Equal(map[string]string{"k": "second"}, labels)
+	require.Equal(map[string]string{"m": "first"}, metadata)
+}
+
+func TestCombineContextExtractorsEmpty(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	combined := slogloki.CombineContextExtractors()
+	labels, metadata := combined(context.Background())
+
+	require.Equal(map[string]string{}, labels)
+	require.Equal(map[string]string{}, metadata)
+}
@@ -0,0 +1,319 @@
+package lokislog
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DedupHandlerOptions configures NewDedupHandler.
+type DedupHandlerOptions struct {
+	// Window a record is suppressed for after an identical record was seen.
+	// Default: 1 minute.
+	DedupWindow time.Duration
+
+	// Maximum number of distinct fingerprints tracked at once. Once
+	// exceeded, the least recently seen fingerprint is evicted.
+	// Default: 1024.
+	MaxEntries int
+}
+
+// NewDedupHandler wraps inner (e.g. a *LokiHandler) and collapses bursts of
+// identical records within DedupWindow into a single record, plus one
+// synthetic summary record once the burst subsides. Records are considered
+// identical if they share the same level, message and effective attribute
+// set (i.e. after WithAttrs/WithGroup have been applied).
+//
+// The returned handler owns a background goroutine that sweeps for expired
+// bursts; call Close on it (or on any handler derived from it via
+// WithAttrs/WithGroup) once it is no longer needed to stop that goroutine.
+func NewDedupHandler(inner slog.Handler, o DedupHandlerOptions) slog.Handler {
+	if o.DedupWindow <= 0 {
+		o.DedupWindow = time.Minute
+	}
+	if o.MaxEntries <= 0 {
+		o.MaxEntries = 1024
+	}
+
+	state := &dedupState{
+		window:     o.DedupWindow,
+		maxEntries: o.MaxEntries,
+		entries:    map[string]*list.Element{},
+		order:      list.New(),
+		done:       make(chan struct{}),
+	}
+	go state.run()
+
+	return &DedupHandler{
+		inner: inner,
+		state: state,
+	}
+}
+
+var _ slog.Handler = (*DedupHandler)(nil)
+
+// DedupHandler suppresses repeated records before they reach the wrapped
+// handler. See NewDedupHandler.
+type DedupHandler struct {
+	inner slog.Handler
+	state *dedupState
+
+	// fpAttrs is the flattened, group-prefixed attribute set accumulated via
+	// WithAttrs/WithGroup so far. It exists purely for fingerprinting; the
+	// actual forwarding to inner uses inner.WithAttrs/WithGroup directly.
+	fpAttrs map[string]string
+	group   []string
+}
+
+func (h *DedupHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.inner.Enabled(ctx, level)
+}
+
+// Close stops the background goroutine that sweeps for expired bursts. It is
+// shared by every handler derived from the original via WithAttrs/WithGroup,
+// so it only needs to be called once, and is safe to call more than once.
+func (h *DedupHandler) Close() error {
+	h.state.stop()
+	return nil
+}
+
+func (h *DedupHandler) Handle(ctx context.Context, record slog.Record) error {
+	attrs := make([]slog.Attr, 0, record.NumAttrs())
+	record.Attrs(func(a slog.Attr) bool {
+		attrs = append(attrs, a)
+		return true
+	})
+
+	fp := h.fingerprint(record.Level, record.Message, attrs)
+
+	if suppress := h.state.observe(fp, record, attrs, h); suppress {
+		return nil
+	}
+
+	return h.inner.Handle(ctx, record)
+}
+
+func (h *DedupHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	fpAttrs := copyStringMap(h.fpAttrs)
+	flattenAttrs(h.group, attrs, fpAttrs)
+
+	return &DedupHandler{
+		inner: h.inner.WithAttrs(attrs),
+		state: h.state,
+
+		fpAttrs: fpAttrs,
+		group:   h.group,
+	}
+}
+
+func (h *DedupHandler) WithGroup(name string) slog.Handler {
+	return &DedupHandler{
+		inner: h.inner.WithGroup(name),
+		state: h.state,
+
+		fpAttrs: h.fpAttrs,
+		group:   append(append([]string{}, h.group...), name),
+	}
+}
+
+func (h *DedupHandler) fingerprint(level slog.Level, msg string, attrs []slog.Attr) string {
+	fpAttrs := copyStringMap(h.fpAttrs)
+	flattenAttrs(h.group, attrs, fpAttrs)
+
+	keys := make([]string, 0, len(fpAttrs))
+	for k := range fpAttrs {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+
+	var b strings.Builder
+	b.WriteString(level.String())
+	b.WriteByte('\x00')
+	b.WriteString(msg)
+	for _, k := range keys {
+		b.WriteByte('\x00')
+		b.WriteString(k)
+		b.WriteByte('=')
+		b.WriteString(fpAttrs[k])
+	}
+	return b.String()
+}
+
+func flattenAttrs(group []string, attrs []slog.Attr, out map[string]string) {
+	for _, a := range attrs {
+		if a.Value.Kind() == slog.KindGroup {
+			flattenAttrs(append(append([]string{}, group...), a.Key), a.Value.Group(), out)
+			continue
+		}
+
+		key := a.Key
+		if len(group) > 0 {
+			key = strings.Join(append(append([]string{}, group...), a.Key), ".")
+		}
+		out[key] = a.Value.String()
+	}
+}
+
+func copyStringMap(m map[string]string) map[string]string {
+	out := make(map[string]string, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
+
+// cacheEntry tracks a burst of records sharing a fingerprint.
+type cacheEntry struct {
+	fp        string
+	count     int
+	firstSeen time.Time
+	lastSeen  time.Time
+
+	level   slog.Level
+	message string
+	attrs   []slog.Attr
+	handler *DedupHandler
+}
+
+// dedupState is the LRU cache and background flusher shared by a DedupHandler
+// and all handlers derived from it via WithAttrs/WithGroup.
+type dedupState struct {
+	mu         sync.Mutex
+	window     time.Duration
+	maxEntries int
+	entries    map[string]*list.Element
+	order      *list.List // front = most recently seen
+
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// stop shuts down the background sweep goroutine. Safe to call more than
+// once.
+func (s *dedupState) stop() {
+	s.stopOnce.Do(func() { close(s.done) })
+}
+
+// observe records that a record with fingerprint fp was just seen. It
+// returns true if the record should be suppressed (a live duplicate was seen
+// within the window), or false if it is the first occurrence and should be
+// forwarded by the caller.
+func (s *dedupState) observe(fp string, record slog.Record, attrs []slog.Attr, h *DedupHandler) bool {
+	s.mu.Lock()
+
+	now := record.Time
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	var stale *cacheEntry
+	if elem, ok := s.entries[fp]; ok {
+		entry := elem.Value.(*cacheEntry)
+		if now.Sub(entry.lastSeen) < s.window {
+			entry.count++
+			entry.lastSeen = now
+			s.order.MoveToFront(elem)
+			s.mu.Unlock()
+			return true
+		}
+		// expired but not swept yet: close out the old burst and start anew.
+		s.order.Remove(elem)
+		delete(s.entries, fp)
+		stale = entry
+	}
+
+	entry := &cacheEntry{
+		fp:        fp,
+		count:     1,
+		firstSeen: now,
+		lastSeen:  now,
+		level:     record.Level,
+		message:   record.Message,
+		attrs:     attrs,
+		handler:   h,
+	}
+	elem := s.order.PushFront(entry)
+	s.entries[fp] = elem
+
+	var evicted *cacheEntry
+	if len(s.entries) > s.maxEntries {
+		evicted = s.evictOldestLocked()
+	}
+	s.mu.Unlock()
+
+	if stale != nil && stale.count > 1 {
+		s.flushEntry(stale)
+	}
+	if evicted != nil && evicted.count > 1 {
+		s.flushEntry(evicted)
+	}
+
+	return false
+}
+
+// evictOldestLocked removes and returns the least recently seen entry, or
+// nil if the cache is empty. The caller holds s.mu; it must flush the
+// returned entry, if any, after unlocking, since flushEntry must not be
+// called while s.mu is held.
+func (s *dedupState) evictOldestLocked() *cacheEntry {
+	elem := s.order.Back()
+	if elem == nil {
+		return nil
+	}
+	entry := elem.Value.(*cacheEntry)
+	s.order.Remove(elem)
+	delete(s.entries, entry.fp)
+	return entry
+}
+
+func (s *dedupState) run() {
+	ticker := time.NewTicker(s.window)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case now := <-ticker.C:
+			s.sweep(now)
+		}
+	}
+}
+
+func (s *dedupState) sweep(now time.Time) {
+	s.mu.Lock()
+	var expired []*cacheEntry
+	for fp, elem := range s.entries {
+		entry := elem.Value.(*cacheEntry)
+		if now.Sub(entry.lastSeen) < s.window {
+			continue
+		}
+		s.order.Remove(elem)
+		delete(s.entries, fp)
+		expired = append(expired, entry)
+	}
+	s.mu.Unlock()
+
+	for _, entry := range expired {
+		if entry.count > 1 {
+			s.flushEntry(entry)
+		}
+	}
+}
+
+// flushEntry emits a synthetic summary record for a burst of suppressed
+// duplicates through the handler that observed them. Must not be called
+// while s.mu is held, since it calls into the inner handler.
+func (s *dedupState) flushEntry(entry *cacheEntry) {
+	repeated := entry.count - 1
+	msg := fmt.Sprintf("%s (repeated %d times in %s)", entry.message, repeated, entry.lastSeen.Sub(entry.firstSeen))
+
+	record := slog.NewRecord(entry.lastSeen, entry.level, msg, 0)
+	record.AddAttrs(entry.attrs...)
+	_ = entry.handler.inner.Handle(context.Background(), record)
+}
@@ -0,0 +1,208 @@
+package lokislog_test
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	slogloki "github.com/royalcat/loki/slog"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingHandler's Handle is called from dedupState's background sweep
+// goroutine (dedup.go's flushEntry) concurrently with the owning test
+// reading back records, so records and its length/contents must only ever
+// be touched through mu.
+type recordingHandler struct {
+	mu      *sync.Mutex
+	records *[]slog.Record
+	attrs   []slog.Attr
+	group   string
+}
+
+func newRecordingHandler(records *[]slog.Record) *recordingHandler {
+	return &recordingHandler{mu: &sync.Mutex{}, records: records}
+}
+
+func (h *recordingHandler) Enabled(context.Context, slog.Level) bool { return true }
+
+func (h *recordingHandler) Handle(_ context.Context, r slog.Record) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	*h.records = append(*h.records, r)
+	return nil
+}
+
+func (h *recordingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &recordingHandler{mu: h.mu, records: h.records, attrs: append(h.attrs, attrs...), group: h.group}
+}
+
+func (h *recordingHandler) WithGroup(name string) slog.Handler {
+	return &recordingHandler{mu: h.mu, records: h.records, attrs: h.attrs, group: name}
+}
+
+// snapshot returns a copy of the records recorded so far, safe to inspect
+// without racing the background sweep goroutine that may still be calling
+// Handle.
+func (h *recordingHandler) snapshot() []slog.Record {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	out := make([]slog.Record, len(*h.records))
+	copy(out, *h.records)
+	return out
+}
+
+func TestDedupHandlerSuppressesBurst(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	var records []slog.Record
+	inner := newRecordingHandler(&records)
+	handler := slogloki.NewDedupHandler(inner, slogloki.DedupHandlerOptions{
+		DedupWindow: time.Minute,
+	})
+
+	ctx := context.Background()
+	now := time.Now()
+	for i := 0; i < 5; i++ {
+		rec := slog.Record{Time: now, Message: "boom", Level: slog.LevelError}
+		require.Nil(handler.Handle(ctx, rec))
+	}
+
+	require.Len(records, 1)
+	require.Equal("boom", records[0].Message)
+}
+
+func TestDedupHandlerFlushesSummaryOnceExpired(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	var records []slog.Record
+	inner := newRecordingHandler(&records)
+	handler := slogloki.NewDedupHandler(inner, slogloki.DedupHandlerOptions{
+		DedupWindow: 20 * time.Millisecond,
+	})
+
+	ctx := context.Background()
+	now := time.Now()
+	for i := 0; i < 3; i++ {
+		rec := slog.Record{Time: now, Message: "boom", Level: slog.LevelError}
+		require.Nil(handler.Handle(ctx, rec))
+	}
+
+	require.Eventually(func() bool {
+		return len(inner.snapshot()) == 2
+	}, time.Second, 5*time.Millisecond)
+
+	require.Contains(inner.snapshot()[1].Message, "repeated 2 times")
+}
+
+func TestDedupHandlerFlushesSummaryOnEviction(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	var records []slog.Record
+	inner := newRecordingHandler(&records)
+	handler := slogloki.NewDedupHandler(inner, slogloki.DedupHandlerOptions{
+		DedupWindow: time.Minute,
+		MaxEntries:  1,
+	})
+
+	ctx := context.Background()
+	now := time.Now()
+
+	rec1 := slog.Record{Time: now, Message: "first", Level: slog.LevelError}
+	require.Nil(handler.Handle(ctx, rec1))
+	require.Nil(handler.Handle(ctx, rec1)) // repeat, bumps count to 2
+
+	// A distinct fingerprint evicts "first" since MaxEntries is 1. Eviction
+	// must flush its summary without deadlocking on dedupState.mu, and the
+	// triggering "second" record must still go through right after it.
+	rec2 := slog.Record{Time: now, Message: "second", Level: slog.LevelError}
+	require.Nil(handler.Handle(ctx, rec2))
+
+	require.Eventually(func() bool {
+		return len(inner.snapshot()) == 3
+	}, time.Second, 5*time.Millisecond)
+
+	got := inner.snapshot()
+	require.Equal("first", got[0].Message)
+	require.Contains(got[1].Message, "repeated 1 times")
+	require.Equal("second", got[2].Message)
+}
+
+func TestDedupHandlerSummaryKeepsAttrs(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	var records []slog.Record
+	inner := newRecordingHandler(&records)
+	handler := slogloki.NewDedupHandler(inner, slogloki.DedupHandlerOptions{
+		DedupWindow: 20 * time.Millisecond,
+	})
+
+	ctx := context.Background()
+	now := time.Now()
+	for i := 0; i < 3; i++ {
+		rec := slog.Record{Time: now, Message: "boom", Level: slog.LevelError}
+		rec.AddAttrs(slog.Int("user_id", 42))
+		require.Nil(handler.Handle(ctx, rec))
+	}
+
+	require.Eventually(func() bool {
+		return len(inner.snapshot()) == 2
+	}, time.Second, 5*time.Millisecond)
+
+	attrs := map[string]string{}
+	inner.snapshot()[1].Attrs(func(a slog.Attr) bool {
+		attrs[a.Key] = a.Value.String()
+		return true
+	})
+	require.Equal(map[string]string{"user_id": "42"}, attrs)
+}
+
+func TestDedupHandlerCloseStopsBackgroundGoroutine(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	var records []slog.Record
+	inner := newRecordingHandler(&records)
+	handler := slogloki.NewDedupHandler(inner, slogloki.DedupHandlerOptions{
+		DedupWindow: 5 * time.Millisecond,
+	})
+
+	before := runtime.NumGoroutine()
+	require.Nil(handler.(*slogloki.DedupHandler).Close())
+
+	require.Eventually(func() bool {
+		return runtime.NumGoroutine() < before
+	}, time.Second, 5*time.Millisecond)
+
+	// Safe to call more than once.
+	require.Nil(handler.(*slogloki.DedupHandler).Close())
+}
+
+func TestDedupHandlerDistinguishesAttrs(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	var records []slog.Record
+	inner := newRecordingHandler(&records)
+	handler := slogloki.NewDedupHandler(inner, slogloki.DedupHandlerOptions{
+		DedupWindow: time.Minute,
+	})
+
+	ctx := context.Background()
+	now := time.Now()
+
+	a := handler.WithAttrs([]slog.Attr{slog.String("k", "a")})
+	b := handler.WithAttrs([]slog.Attr{slog.String("k", "b")})
+
+	require.Nil(a.Handle(ctx, slog.Record{Time: now, Message: "boom", Level: slog.LevelError}))
+	require.Nil(b.Handle(ctx, slog.Record{Time: now, Message: "boom", Level: slog.LevelError}))
+
+	require.Len(records, 2)
+}
@@ -30,6 +30,15 @@ type HandlerOptions struct {
 	//
 	// By default any label in group "static_metadata" or longer than 1024 symbols will be moved to static metadata
 	LabelHandler LabelHandler
+
+	// Extracts additional labels/metadata from the record's context.Context, merged in on every Handle call.
+	// See TraceContextExtractor and WithContextLabels/ContextLabelsExtractor for built-ins.
+	ContextExtractor ContextExtractor
+
+	// Name of the label (after flattening, e.g. "group_key") whose value should
+	// be forwarded as the "tenant" label, for routing by loki.ClientOptions.TenantResolver.
+	// The attribute is renamed, not duplicated: it is removed from its original key.
+	TenantKey string
 }
 
 var lableNameRegex = regexp.MustCompile("^[a-zA-Z_:][a-zA-Z0-9_:]*$")
@@ -51,7 +60,15 @@ func NewHandler(client loki.Client, o HandlerOptions) (slog.Handler, error) {
 	}
 	if o.LabelHandler == nil {
 		o.LabelHandler = func(groups []string, attr slog.Attr) (isLabel bool, keyOverwrite string) {
-			return false, ""
+			for _, g := range groups {
+				if g == "static_metadata" {
+					return false, ""
+				}
+			}
+			if len(attr.Value.String()) > 1024 {
+				return false, ""
+			}
+			return true, ""
 		}
 	}
 
@@ -61,6 +78,9 @@ func NewHandler(client loki.Client, o HandlerOptions) (slog.Handler, error) {
 
 		lb: newLabelBuilder(o.GroupSplitter, o.LabelHandler).withAttrs(o.DefaultAttrs),
 
+		contextExtractor: o.ContextExtractor,
+		tenantKey:        o.TenantKey,
+
 		client: client,
 	}, nil
 }
@@ -74,19 +94,42 @@ type LokiHandler struct {
 	levelKey string
 
 	lb labelBuilder
+
+	contextExtractor ContextExtractor
+	tenantKey        string
 }
 
 func (h *LokiHandler) Enabled(_ context.Context, level slog.Level) bool {
 	return level >= h.level.Level()
 }
 
-func (h *LokiHandler) Handle(_ context.Context, record slog.Record) error {
+func (h *LokiHandler) Handle(ctx context.Context, record slog.Record) error {
 	labels := make(map[string]string, 1+len(h.lb.labels))
 	metadata := make(map[string]string, len(h.lb.metadata))
 
 	labels, metadata = h.lb.build(labels, metadata)
 	labels, metadata = newLabelBuilder(h.lb.groupSplitter, h.lb.LabelHandler).withAttrs(getAttrs(record)).build(labels, metadata)
 
+	if h.contextExtractor != nil {
+		ctxLabels, ctxMetadata := h.contextExtractor(ctx)
+		for k, v := range ctxLabels {
+			labels[k] = v
+		}
+		for k, v := range ctxMetadata {
+			metadata[k] = v
+		}
+	}
+
+	if h.tenantKey != "" {
+		if v, ok := labels[h.tenantKey]; ok {
+			delete(labels, h.tenantKey)
+			labels["tenant"] = v
+		} else if v, ok := metadata[h.tenantKey]; ok {
+			delete(metadata, h.tenantKey)
+			labels["tenant"] = v
+		}
+	}
+
 	labels[h.levelKey] = record.Level.String()
 
 	h.client.Log(record.Time, record.Message, labels, metadata)
@@ -177,6 +220,9 @@ func (h *LokiHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
 		levelKey: h.levelKey,
 
 		lb: h.lb.withAttrs(attrs),
+
+		contextExtractor: h.contextExtractor,
+		tenantKey:        h.tenantKey,
 	}
 }
 
@@ -188,6 +234,9 @@ func (h *LokiHandler) WithGroup(name string) slog.Handler {
 		levelKey: h.levelKey,
 
 		lb: h.lb.withGroup(name),
+
+		contextExtractor: h.contextExtractor,
+		tenantKey:        h.tenantKey,
 	}
 }
 
@@ -40,6 +40,16 @@ func (*MockedClient) Flush(ctx context.Context) {
 func (*MockedClient) Shutdown(ctx context.Context) {
 }
 
+// ReplayDeadLetter implements loki.Client.
+func (*MockedClient) ReplayDeadLetter(ctx context.Context) error {
+	return nil
+}
+
+// Stats implements loki.Client.
+func (*MockedClient) Stats() loki.Stats {
+	return loki.Stats{}
+}
+
 // Log implements loki.Client.
 func (m *MockedClient) Log(ts time.Time, msg string, lables map[string]string, metadata map[string]string) {
 	m.Called(ts, msg, lables, metadata)
@@ -133,3 +143,94 @@ func TestHandle(t *testing.T) {
 		require.Nil(err)
 	}
 }
+
+func TestHandleContextExtractor(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	require := require.New(t)
+	client := MockedClient{}
+	handler, err := slogloki.NewHandler(&client, slogloki.HandlerOptions{
+		ContextExtractor: slogloki.ContextLabelsExtractor,
+	})
+	require.Nil(err)
+
+	ctx = slogloki.WithContextLabels(ctx, map[string]string{"tenant": "acme"})
+
+	rec := slog.Record{
+		Time:    time.Now(),
+		Message: "start",
+		Level:   slog.LevelInfo,
+	}
+	client.On("Log",
+		rec.Time,
+		rec.Message,
+		map[string]string{
+			"tenant": "acme",
+			"level":  rec.Level.String(),
+		},
+		map[string]string{},
+	).Return()
+	err = handler.Handle(ctx, rec)
+	require.Nil(err)
+}
+
+func TestHandleTenantKey(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	require := require.New(t)
+	client := MockedClient{}
+	handler, err := slogloki.NewHandler(&client, slogloki.HandlerOptions{
+		TenantKey: "org_id",
+	})
+	require.Nil(err)
+	handler = handler.WithAttrs([]slog.Attr{slog.String("org_id", "acme")})
+
+	rec := slog.Record{
+		Time:    time.Now(),
+		Message: "start",
+		Level:   slog.LevelInfo,
+	}
+	client.On("Log",
+		rec.Time,
+		rec.Message,
+		map[string]string{
+			"tenant": "acme",
+			"level":  rec.Level.String(),
+		},
+		map[string]string{},
+	).Return()
+	err = handler.Handle(ctx, rec)
+	require.Nil(err)
+}
+
+// TestHandleTenantKeyAlreadyTenant covers TenantKey: "tenant", whose own
+// key already equals "tenant": the promotion block's delete-then-reinsert
+// must stay a correct no-op rather than being skipped.
+func TestHandleTenantKeyAlreadyTenant(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	require := require.New(t)
+	client := MockedClient{}
+	handler, err := slogloki.NewHandler(&client, slogloki.HandlerOptions{
+		TenantKey: "tenant",
+	})
+	require.Nil(err)
+	handler = handler.WithAttrs([]slog.Attr{slog.String("tenant", "acme")})
+
+	rec := slog.Record{
+		Time:    time.Now(),
+		Message: "start",
+		Level:   slog.LevelInfo,
+	}
+	client.On("Log",
+		rec.Time,
+		rec.Message,
+		map[string]string{
+			"tenant": "acme",
+			"level":  rec.Level.String(),
+		},
+		map[string]string{},
+	).Return()
+	err = handler.Handle(ctx, rec)
+	require.Nil(err)
+}
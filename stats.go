@@ -0,0 +1,38 @@
+package loki
+
+import "sync/atomic"
+
+// Stats is a point-in-time snapshot of a Client's ingestion and delivery
+// counters. Enqueued/Dropped count individual Log calls; Sent/Failed count
+// batches; Retried counts retry attempts.
+type Stats struct {
+	Enqueued      uint64
+	Dropped       uint64
+	Sent          uint64
+	Failed        uint64
+	Retried       uint64
+	BytesInFlight uint64
+}
+
+// clientStats holds the atomic counters backing Stats. Kept as a separate
+// struct so it can be embedded by value in clientImpl without repeating
+// "atomic.Uint64" six times inline.
+type clientStats struct {
+	enqueued      atomic.Uint64
+	dropped       atomic.Uint64
+	sent          atomic.Uint64
+	failed        atomic.Uint64
+	retried       atomic.Uint64
+	bytesInFlight atomic.Uint64
+}
+
+func (s *clientStats) snapshot() Stats {
+	return Stats{
+		Enqueued:      s.enqueued.Load(),
+		Dropped:       s.dropped.Load(),
+		Sent:          s.sent.Load(),
+		Failed:        s.failed.Load(),
+		Retried:       s.retried.Load(),
+		BytesInFlight: s.bytesInFlight.Load(),
+	}
+}